@@ -9,6 +9,7 @@ import (
 
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 )
 
@@ -246,6 +247,770 @@ func ParallelSearchSubstring(haystack, needle string, n_ranges int) []int {
 	return parallelFindMatches(haystack, needle, n_prefix, ranges)
 }
 
+// --- Aho–Corasick algorithm implementation
+
+// A single match produced by the Aho–Corasick scanner: the index of the
+// needle (in the order passed to MultiSearch) and the offset of the last
+// byte of the match in the haystack.
+type Match struct {
+	PatternIndex int
+	Offset       int
+}
+
+// acNode: one state of the goto trie.
+// children: goto transitions explicitly present in the trie
+// fail: deepest proper suffix of this state's path that is also a trie
+//       prefix (the dictionary-suffix/output link is folded into ‘output’)
+// output: indices of needles ending at this state, including needles
+//         ending at any state reachable by following fail links
+type acNode struct {
+	children map[byte]int
+	fail     int
+	output   []int
+}
+
+// Follow the goto function from state ‘v’ on byte ‘c’, falling back through
+// fail links when there’s no explicit transition. Mirrors the fallback loop
+// in prefixAt, but over the automaton instead of a single KMP prefix table.
+func acGoto(nodes []acNode, v int, c byte) int {
+	for {
+		if next, ok := nodes[v].children[c]; ok {
+			return next
+		}
+		if v == 0 {
+			return 0
+		}
+		v = nodes[v].fail
+	}
+}
+
+// Build the goto trie over ‘needles’, then compute fail and output links
+// by a BFS over the trie (fail[v] is only defined once fail[parent(v)] is
+// known, so a BFS order is required).
+func buildAutomaton(needles []string) []acNode {
+	nodes := []acNode{{children: make(map[byte]int)}}
+
+	for pi, needle := range needles {
+		// Empty needles never advance past the root, and unlike every
+		// other state the root is never revisited via a fail link, so
+		// recording them here would only surface them on an incidental
+		// goto-back-to-root rather than at every offset. They're handled
+		// separately, by emptyPatternMatches.
+		if len(needle) == 0 {
+			continue
+		}
+
+		cur := 0
+		for i := 0; i < len(needle); i++ {
+			c := needle[i]
+			next, ok := nodes[cur].children[c]
+			if !ok {
+				nodes = append(nodes, acNode{children: make(map[byte]int)})
+				next = len(nodes) - 1
+				nodes[cur].children[c] = next
+			}
+			cur = next
+		}
+		nodes[cur].output = append(nodes[cur].output, pi)
+	}
+
+	queue := make([]int, 0, len(nodes))
+	for _, v := range nodes[0].children {
+		queue = append(queue, v)
+	}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+
+		for c, v := range nodes[u].children {
+			nodes[v].fail = acGoto(nodes, nodes[u].fail, c)
+			nodes[v].output = append(
+				nodes[v].output, nodes[nodes[v].fail].output...,
+			)
+			queue = append(queue, v)
+		}
+	}
+
+	return nodes
+}
+
+// haystack: the string to search in
+// nodes: automaton built by buildAutomaton
+// initial: initial automaton state. Useful when chaining multiple calls
+// offset: the value to add to match offsets. Useful when splitting search
+func findMultiMatches(
+	haystack string,
+	nodes []acNode,
+	initial int,
+	offset int,
+) ([]Match, int) {
+	var matches []Match
+	cur := initial
+	for i := 0; i < len(haystack); i++ {
+		cur = acGoto(nodes, cur, haystack[i])
+		real_idx := offset + i
+
+		for _, pi := range nodes[cur].output {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Match of pattern %d at %d\n",
+					pi, real_idx)
+			}
+			matches = append(matches, Match{pi, real_idx})
+		}
+	}
+	return matches, cur
+}
+
+// MultiSearch scans ‘haystack’ once for every needle in ‘needles’ and
+// returns every match (including overlapping matches, matches of needles
+// that are suffixes of other needles, and matches of empty needles),
+// ordered by offset, pairing each with its pattern index.
+func MultiSearch(haystack string, needles []string) []Match {
+	nodes := buildAutomaton(needles)
+	matches, _ := findMultiMatches(haystack, nodes, 0, 0)
+	matches = append(
+		matches, emptyPatternMatches(needles, len(haystack), 0)...,
+	)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Offset < matches[j].Offset
+	})
+	return matches
+}
+
+// An empty needle matches at every one of the len(haystack)+1 offsets in
+// ‘haystack’ (before the first byte, between every pair of bytes, and
+// after the last), regardless of what any other needle is doing, so it's
+// simplest to report it separately rather than fold it into the
+// automaton scan.
+func emptyPatternMatches(needles []string, length int, offset int) []Match {
+	var empty []int
+	for pi, needle := range needles {
+		if len(needle) == 0 {
+			empty = append(empty, pi)
+		}
+	}
+	if len(empty) == 0 {
+		return nil
+	}
+
+	matches := make([]Match, 0, (length+1)*len(empty))
+	for i := 0; i <= length; i++ {
+		for _, pi := range empty {
+			matches = append(matches, Match{pi, offset + i})
+		}
+	}
+	return matches
+}
+
+func parallelFindMultiMatches(
+	haystack string,
+	nodes []acNode,
+	ranges []Range,
+) []Match {
+	type searchResult struct {
+		idx int
+		mat []Match
+	}
+
+	finish_ch := make(chan searchResult)
+	for i, rng := range ranges {
+		offset := rng.offset
+		end := offset + rng.length
+		piece := haystack[offset:end]
+		// Ranges overlap, so a match ending before the previous range's
+		// end was already reported by that range.
+		skip_below := 0
+		if i > 0 {
+			skip_below = ranges[i-1].offset + ranges[i-1].length
+		}
+		go func(i int) {
+			m, _ := findMultiMatches(piece, nodes, 0, offset)
+			own := m[:0]
+			for _, x := range m {
+				if x.Offset >= skip_below {
+					own = append(own, x)
+				}
+			}
+			finish_ch <- searchResult{i, own}
+		}(i)
+	}
+
+	results := make([][]Match, len(ranges))
+	total := 0
+	for i := 0; i < len(ranges); i++ {
+		res := <-finish_ch
+		results[res.idx] = res.mat
+		total += len(res.mat)
+	}
+
+	matches := make([]Match, 0, total)
+	for _, res := range results {
+		matches = append(matches, res...)
+	}
+
+	return matches
+}
+
+// ParallelMultiSearch is MultiSearch split across ‘n_ranges’ goroutines via
+// splitKmpWork, using the longest needle’s length as the prematch area (the
+// shortest span over which a range boundary could split a match).
+func ParallelMultiSearch(haystack string, needles []string, n_ranges int) []Match {
+	nodes := buildAutomaton(needles)
+
+	longest := 0
+	for _, needle := range needles {
+		if len(needle) > longest {
+			longest = len(needle)
+		}
+	}
+
+	// If every needle is empty (or there are none at all), there's
+	// nothing for the automaton to scan for.
+	var matches []Match
+	if longest > 0 {
+		// A haystack shorter than the longest needle is scanned as one
+		// range: splitKmpWork needs room for at least one full window.
+		ranges := []Range{{0, len(haystack)}}
+		if len(haystack) >= longest {
+			ranges = splitKmpWork(len(haystack), longest, n_ranges)
+		}
+		matches = parallelFindMultiMatches(haystack, nodes, ranges)
+	}
+
+	matches = append(
+		matches, emptyPatternMatches(needles, len(haystack), 0)...,
+	)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Offset < matches[j].Offset
+	})
+	return matches
+}
+
+// readPatterns reads one needle per line from ‘path’.
+func readPatterns(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		patterns = append(patterns, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+func printMultiMatches(matches []Match) {
+	for _, m := range matches {
+		fmt.Printf("%d:%d\n", m.PatternIndex, m.Offset)
+	}
+}
+
+// --- Fuzzy (fzf-style) substring search
+
+// Score constants, roughly following the fzf v2 scoring scheme.
+const (
+	fuzzyScoreMatch          = 16
+	fuzzyBonusConsecutive    = 15
+	fuzzyBonusBoundary       = 10
+	fuzzyBonusExactCase      = 1
+	fuzzyPenaltyGapStart     = 3
+	fuzzyPenaltyGapExtension = 1
+)
+
+// A fuzzy match of ‘needle’ somewhere in ‘Line’: the window start and
+// length of the matched substring, and its score.
+type FuzzyMatch struct {
+	Line   string
+	Score  int
+	Start  int
+	Length int
+}
+
+func isUpperByte(c byte) bool { return c >= 'A' && c <= 'Z' }
+func isLowerByte(c byte) bool { return c >= 'a' && c <= 'z' }
+
+func toLowerByte(c byte) byte {
+	if isUpperByte(c) {
+		return c + ('a' - 'A')
+	}
+	return c
+}
+
+// A position is a boundary if it starts the string, follows one of
+// ‘/’, ‘_’, ‘-’, ‘ ’, or is a camelCase transition (lowercase to uppercase).
+func fuzzyBoundary(s string, j int) bool {
+	if j == 0 {
+		return true
+	}
+	switch s[j-1] {
+	case '/', '_', '-', ' ':
+		return true
+	}
+	return isLowerByte(s[j-1]) && isUpperByte(s[j])
+}
+
+// Locate the tightest window in ‘haystack’ containing ‘needle’ as a
+// subsequence: a greedy left-to-right pass finds the earliest end, then a
+// greedy right-to-left pass from that end finds the latest compatible
+// start.
+func fuzzyBounds(haystack, needle string) (start, end int, ok bool) {
+	pos := 0
+	for i := 0; i < len(needle); i++ {
+		nc := toLowerByte(needle[i])
+		found := -1
+		for j := pos; j < len(haystack); j++ {
+			if toLowerByte(haystack[j]) == nc {
+				found = j
+				break
+			}
+		}
+		if found == -1 {
+			return 0, 0, false
+		}
+		end = found
+		pos = found + 1
+	}
+
+	pos = end
+	for i := len(needle) - 1; i >= 0; i-- {
+		nc := toLowerByte(needle[i])
+		found := -1
+		for j := pos; j >= 0; j-- {
+			if toLowerByte(haystack[j]) == nc {
+				found = j
+				break
+			}
+		}
+		start = found
+		pos = found - 1
+	}
+
+	return start, end, true
+}
+
+// fuzzyScore runs the DP scoring pass inside the window located by
+// fuzzyBounds. h[i][j] is the best score aligning needle[:i] within
+// window[:j]; c[i][j] is the length of the run of consecutive matched
+// characters ending the alignment used by h[i][j], needed to tell a fresh
+// gap from a continuing one when pricing the gap penalty.
+func fuzzyScore(haystack, needle string) (score, start, length int, ok bool) {
+	if len(needle) == 0 || len(haystack) == 0 {
+		return 0, 0, 0, false
+	}
+
+	wstart, wend, ok := fuzzyBounds(haystack, needle)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	window := haystack[wstart : wend+1]
+
+	n := len(needle)
+	m := len(window)
+
+	h := make([][]int, n+1)
+	c := make([][]int, n+1)
+	for i := range h {
+		h[i] = make([]int, m+1)
+		c[i] = make([]int, m+1)
+	}
+
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			var best, consec int
+
+			if toLowerByte(needle[i-1]) == toLowerByte(window[j-1]) {
+				consec = 1
+				if c[i-1][j-1] > 0 {
+					consec = c[i-1][j-1] + 1
+				}
+
+				bonus := fuzzyScoreMatch
+				if consec > 1 {
+					bonus += fuzzyBonusConsecutive
+				}
+				if fuzzyBoundary(window, j-1) {
+					bonus += fuzzyBonusBoundary
+				}
+				if needle[i-1] == window[j-1] {
+					bonus += fuzzyBonusExactCase
+				}
+
+				best = h[i-1][j-1] + bonus
+			}
+
+			if j > 1 {
+				penalty := fuzzyPenaltyGapStart
+				if c[i][j-1] == 0 {
+					penalty = fuzzyPenaltyGapExtension
+				}
+				if gap := h[i][j-1] - penalty; gap > best {
+					best = gap
+					consec = 0
+				}
+			}
+
+			h[i][j] = best
+			c[i][j] = consec
+		}
+	}
+
+	best := 0
+	for j := 1; j <= m; j++ {
+		if h[n][j] > best {
+			best = h[n][j]
+		}
+	}
+
+	return best, wstart, m, true
+}
+
+// topKFuzzyMatches scores ‘needle’ against every line in ‘lines’ and keeps
+// the best ‘k’, ordered by score (desc), then matched-window length (asc),
+// then total line length (asc).
+func topKFuzzyMatches(lines []string, needle string, k int) []FuzzyMatch {
+	var matches []FuzzyMatch
+	for _, line := range lines {
+		score, start, length, ok := fuzzyScore(line, needle)
+		if !ok {
+			continue
+		}
+		matches = append(matches, FuzzyMatch{line, score, start, length})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Length != b.Length {
+			return a.Length < b.Length
+		}
+		if len(a.Line) != len(b.Line) {
+			return len(a.Line) < len(b.Line)
+		}
+		// Final tiebreaker: the first three keys tie often enough in
+		// practice (same score, same window, same line length) that
+		// without this the surviving top-K set would depend on the
+		// order matches were appended in, which for ParallelFuzzyFind
+		// depends on how many ranges split the lines.
+		return a.Line < b.Line
+	})
+
+	if k < 0 {
+		k = 0
+	}
+	if k < len(matches) {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// FuzzyFind scores ‘needle’ against every line of ‘haystack’ and returns
+// the top ‘k’ matches.
+func FuzzyFind(haystack, needle string, k int) []FuzzyMatch {
+	return topKFuzzyMatches(strings.Split(haystack, "\n"), needle, k)
+}
+
+// ParallelFuzzyFind is FuzzyFind split across ‘n_ranges’ goroutines, each
+// scoring its own slice of lines. The line ranges come from splitKmpWork
+// itself (with len_substr=1, so there’s no prematch area to account for —
+// lines are scored independently, unlike byte ranges in a KMP haystack).
+func ParallelFuzzyFind(haystack, needle string, k, n_ranges int) []FuzzyMatch {
+	lines := strings.Split(haystack, "\n")
+	if len(lines) == 0 {
+		return nil
+	}
+
+	ranges := splitKmpWork(len(lines), 1, n_ranges)
+
+	type fuzzyResult struct {
+		idx     int
+		matches []FuzzyMatch
+	}
+
+	finish_ch := make(chan fuzzyResult)
+	for i, rng := range ranges {
+		piece := lines[rng.offset : rng.offset+rng.length]
+		go func(i int, piece []string) {
+			finish_ch <- fuzzyResult{i, topKFuzzyMatches(piece, needle, k)}
+		}(i, piece)
+	}
+
+	var all []FuzzyMatch
+	for range ranges {
+		res := <-finish_ch
+		all = append(all, res.matches...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Length != b.Length {
+			return a.Length < b.Length
+		}
+		if len(a.Line) != len(b.Line) {
+			return len(a.Line) < len(b.Line)
+		}
+		// Same tiebreaker as topKFuzzyMatches, so the top-K set
+		// surviving the final truncation doesn't depend on n_ranges.
+		return a.Line < b.Line
+	})
+
+	if k < 0 {
+		k = 0
+	}
+	if k < len(all) {
+		all = all[:k]
+	}
+	return all
+}
+
+// --- Glob/wildcard search
+
+// A literal fragment of a glob pattern, between two `*`/`**` gaps (or the
+// start/end of the pattern). wild[i] is set wherever text[i] is a `?`.
+// gp is the KMP-style prefix function for (text, wild) under the
+// wildcard-aware comparison in globPrefixAt.
+type globFrag struct {
+	text string
+	wild []bool
+	gp   []int
+}
+
+// collapseGlobStars merges runs of `*` (which also absorbs `**`, kept only
+// for the double-star convention) into a single `*`, so splitting on `*`
+// yields the pattern's literal fragments directly.
+func collapseGlobStars(pattern string) string {
+	out := make([]byte, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '*' && len(out) > 0 && out[len(out)-1] == '*' {
+			continue
+		}
+		out = append(out, pattern[i])
+	}
+	return string(out)
+}
+
+func globWildcards(frag string) []bool {
+	wild := make([]bool, len(frag))
+	for i := 0; i < len(frag); i++ {
+		wild[i] = frag[i] == '?'
+	}
+	return wild
+}
+
+// globPrefixAt mirrors prefixAt, but with the mask predicate from the
+// request replacing plain byte equality: a position matches if either side
+// is a `?` wildcard, or the bytes are equal.
+func globPrefixAt(frag string, wild []bool, gp []int, c byte, cWild bool, i int) int {
+	for {
+		switch {
+		case i < len(frag) && (cWild || wild[i] || frag[i] == c):
+			return i + 1
+		case i == 0:
+			return 0
+		}
+		i = gp[i-1]
+	}
+}
+
+func globPrefix(frag string, wild []bool) []int {
+	if len(frag) == 0 {
+		return nil
+	}
+	gp := make([]int, len(frag))
+	for i := 1; i < len(frag); i++ {
+		gp[i] = globPrefixAt(frag, wild, gp, frag[i], wild[i], gp[i-1])
+	}
+	return gp
+}
+
+// globFindMatches is findMatches generalized to a wildcard-aware fragment.
+// For a fragment with no `?` at all, wild is all false and this behaves
+// exactly like findMatches.
+func globFindMatches(
+	haystack string,
+	frag string,
+	wild []bool,
+	gp []int,
+	initial int,
+	offset int,
+) ([]int, int) {
+	var matches []int
+	prev := initial
+	n := len(frag)
+	for i := 0; i < len(haystack); i++ {
+		prev = globPrefixAt(frag, wild, gp, haystack[i], false, prev)
+		if prev == n {
+			matches = append(matches, offset+i-n+1)
+		}
+	}
+	return matches, prev
+}
+
+// parallelGlobFindMatches is globFindMatches split across ‘n_ranges’
+// goroutines via splitKmpWork, the same way parallelFindMatches splits
+// plain KMP search.
+func parallelGlobFindMatches(haystack string, f globFrag, n_ranges int) []int {
+	type searchResult struct {
+		idx int
+		mat []int
+	}
+
+	// Nothing can match a fragment longer than what's left to search; bail
+	// out before splitKmpWork, which divides by the range count after
+	// clamping it to the (here, non-positive) match area.
+	if len(haystack) < len(f.text) {
+		return nil
+	}
+
+	ranges := splitKmpWork(len(haystack), len(f.text), n_ranges)
+
+	finish_ch := make(chan searchResult)
+	for i, rng := range ranges {
+		offset := rng.offset
+		end := offset + rng.length
+		piece := haystack[offset:end]
+		go func(i int) {
+			m, _ := globFindMatches(piece, f.text, f.wild, f.gp, 0, offset)
+			finish_ch <- searchResult{i, m}
+		}(i)
+	}
+
+	results := make([][]int, len(ranges))
+	total := 0
+	for i := 0; i < len(ranges); i++ {
+		res := <-finish_ch
+		results[res.idx] = res.mat
+		total += len(res.mat)
+	}
+
+	matches := make([]int, 0, total)
+	for _, res := range results {
+		matches = append(matches, res...)
+	}
+	return matches
+}
+
+func globMatchesAt(haystack string, f globFrag, pos int) bool {
+	if pos < 0 || pos+len(f.text) > len(haystack) {
+		return false
+	}
+	for i := 0; i < len(f.text); i++ {
+		if !f.wild[i] && haystack[pos+i] != f.text[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchGlob matches ‘haystack’ against a glob ‘pattern’ supporting `?`
+// (any single byte), `*` and `**` (any run of bytes). The pattern is split
+// on its `*`/`**` gaps into literal fragments, each searched in turn via
+// globFindMatches, constraining the next fragment to start after the
+// previous one's end. The first fragment is pinned to offset 0 unless the
+// pattern starts with a star, and the last is pinned to end at
+// len(haystack) unless the pattern ends with one — so, as with shell
+// globs, the whole haystack must match. Returns []int{0} on a match, or
+// nil otherwise.
+func SearchGlob(haystack, pattern string, n_ranges int) []int {
+	collapsed := collapseGlobStars(pattern)
+	leadingStar := strings.HasPrefix(collapsed, "*")
+	trailingStar := strings.HasSuffix(collapsed, "*")
+	literals := strings.Split(collapsed, "*")
+
+	frags := make([]globFrag, len(literals))
+	for i, lit := range literals {
+		wild := globWildcards(lit)
+		frags[i] = globFrag{lit, wild, globPrefix(lit, wild)}
+	}
+
+	pos := 0
+	for i, f := range frags {
+		if len(f.text) == 0 {
+			continue
+		}
+
+		switch {
+		case i == 0 && !leadingStar:
+			if !globMatchesAt(haystack, f, 0) {
+				return nil
+			}
+			pos = len(f.text)
+
+		case i == len(frags)-1 && !trailingStar:
+			start := len(haystack) - len(f.text)
+			if start < pos || !globMatchesAt(haystack, f, start) {
+				return nil
+			}
+			pos = len(haystack)
+
+		default:
+			matches := parallelGlobFindMatches(haystack[pos:], f, n_ranges)
+			if len(matches) == 0 {
+				return nil
+			}
+			pos += matches[0] + len(f.text)
+		}
+	}
+
+	if !trailingStar && pos != len(haystack) {
+		return nil
+	}
+
+	return []int{0}
+}
+
+func printFuzzyMatches(matches []FuzzyMatch) {
+	for _, m := range matches {
+		fmt.Printf("%d:%s\n", m.Score, m.Line)
+	}
+}
+
+// --- Streaming search
+
+// Chunk size for StreamSearch. Large enough to amortize the per-Read
+// overhead, small enough to keep memory use flat regardless of input size.
+const streamChunkSize = 64 * 1024
+
+// StreamSearch searches for ‘needle’ in ‘r’ without ever holding the whole
+// input in memory: it reads fixed-size chunks and carries the prefixAt
+// state (the ‘prev’ returned by findMatches) across chunk boundaries, so
+// matches straddling a chunk boundary are still found, and reports each
+// match's absolute offset to ‘out’ as it's found.
+func StreamSearch(r io.Reader, needle string, out func(offset int64)) {
+	n_prefix := prefix(needle)
+
+	buf := make([]byte, streamChunkSize)
+	prev := 0
+	var total int64
+
+	for {
+		nr, err := r.Read(buf)
+		if nr > 0 {
+			matches, next := findMatches(
+				string(buf[:nr]), needle, n_prefix, prev, int(total),
+			)
+			for _, m := range matches {
+				out(int64(m))
+			}
+			prev = next
+			total += int64(nr)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
 func printOffsets(off []int) {
 	if len(off) == 0 {
 		fmt.Println(-1)
@@ -271,6 +1036,12 @@ func readLine(rd *bufio.Reader) (string, error) {
 
 func main() {
 	var n_threads int
+	var pattern_file string
+	var fuzzy bool
+	var top_k int
+	var glob bool
+	var stream_pattern_file string
+	var count_only bool
 
 	flag.IntVar(
 		&n_threads, "j", 1,
@@ -280,10 +1051,94 @@ func main() {
 		&verbose, "v", false,
 		"Show detailed info on algorithm's execution",
 	)
+	flag.StringVar(
+		&pattern_file, "a", "",
+		"Multi-pattern (Aho–Corasick) mode: read needles, one per "+
+			"line, from `file`, and the haystack from stdin",
+	)
+	flag.BoolVar(
+		&fuzzy, "f", false,
+		"Fuzzy substring mode: rank stdin's lines by fzf-style "+
+			"match score against the needle instead of exact KMP",
+	)
+	flag.IntVar(
+		&top_k, "k", 10,
+		"Number of top fuzzy matches to print (with -f)",
+	)
+	flag.BoolVar(
+		&glob, "g", false,
+		"Glob mode: match the whole haystack against a `?`/`*`/`**` "+
+			"pattern instead of exact KMP",
+	)
+	flag.StringVar(
+		&stream_pattern_file, "p", "",
+		"Streaming mode: read the needle from `file` and search "+
+			"stdin chunk by chunk, without buffering it whole",
+	)
+	flag.BoolVar(
+		&count_only, "c", false,
+		"Print only the total match count (with -p)",
+	)
 	flag.Parse()
 
 	buf_rd := bufio.NewReader(os.Stdin)
 
+	if stream_pattern_file != "" {
+		needle_bytes, err := os.ReadFile(stream_pattern_file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		needle := strings.TrimSuffix(string(needle_bytes), "\n")
+
+		count := 0
+		out := func(offset int64) {
+			if count_only {
+				count++
+			} else {
+				fmt.Println(offset)
+			}
+		}
+
+		StreamSearch(buf_rd, needle, out)
+
+		if count_only {
+			fmt.Println(count)
+		}
+		return
+	}
+
+	if fuzzy {
+		needle, err := readLine(buf_rd)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		rest, err := io.ReadAll(buf_rd)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		res := ParallelFuzzyFind(string(rest), needle, top_k, n_threads)
+		printFuzzyMatches(res)
+		return
+	}
+
+	if pattern_file != "" {
+		patterns, err := readPatterns(pattern_file)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		haystack, err := io.ReadAll(buf_rd)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		res := ParallelMultiSearch(string(haystack), patterns, n_threads)
+		printMultiMatches(res)
+		return
+	}
+
 	str_a, err := readLine(buf_rd)
 	if err != nil {
 		log.Fatal(err)
@@ -294,7 +1149,12 @@ func main() {
 		log.Fatal(err)
 	}
 
-	res := ParallelSearchSubstring(str_b, str_a, n_threads)
+	var res []int
+	if glob {
+		res = SearchGlob(str_b, str_a, n_threads)
+	} else {
+		res = ParallelSearchSubstring(str_b, str_a, n_threads)
+	}
 
 	printOffsets(res)
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func offsetsOf(matches []Match, pi int) []int {
+	var out []int
+	for _, m := range matches {
+		if m.PatternIndex == pi {
+			out = append(out, m.Offset)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+func TestMultiSearchOverlapping(t *testing.T) {
+	// "aa" and "a" both match within "aaa", overlapping at every position.
+	matches := MultiSearch("aaa", []string{"aa", "a"})
+
+	gotAA, wantAA := offsetsOf(matches, 0), []int{1, 2}
+	if !reflect.DeepEqual(gotAA, wantAA) {
+		t.Errorf("pattern 0 (\"aa\") offsets = %v, want %v", gotAA, wantAA)
+	}
+
+	gotA, wantA := offsetsOf(matches, 1), []int{0, 1, 2}
+	if !reflect.DeepEqual(gotA, wantA) {
+		t.Errorf("pattern 1 (\"a\") offsets = %v, want %v", gotA, wantA)
+	}
+}
+
+func TestMultiSearchSuffixPattern(t *testing.T) {
+	// "ab" is a suffix of "cab"; both must be reported where "cab" occurs.
+	matches := MultiSearch("xcabx", []string{"cab", "ab"})
+
+	gotCab, wantCab := offsetsOf(matches, 0), []int{3}
+	if !reflect.DeepEqual(gotCab, wantCab) {
+		t.Errorf("pattern 0 (\"cab\") offsets = %v, want %v", gotCab, wantCab)
+	}
+
+	gotAb, wantAb := offsetsOf(matches, 1), []int{3}
+	if !reflect.DeepEqual(gotAb, wantAb) {
+		t.Errorf("pattern 1 (\"ab\") offsets = %v, want %v", gotAb, wantAb)
+	}
+}
+
+func TestMultiSearchEmptyPattern(t *testing.T) {
+	matches := MultiSearch("xyz", []string{""})
+
+	got, want := offsetsOf(matches, 0), []int{0, 1, 2, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("empty pattern offsets = %v, want %v", got, want)
+	}
+}
+
+func TestMultiSearchEmptyPatternAlongsideOthers(t *testing.T) {
+	matches := MultiSearch("aaaa", []string{"", "a"})
+
+	gotEmpty, wantEmpty := offsetsOf(matches, 0), []int{0, 1, 2, 3, 4}
+	if !reflect.DeepEqual(gotEmpty, wantEmpty) {
+		t.Errorf("empty pattern offsets = %v, want %v", gotEmpty, wantEmpty)
+	}
+
+	gotA, wantA := offsetsOf(matches, 1), []int{0, 1, 2, 3}
+	if !reflect.DeepEqual(gotA, wantA) {
+		t.Errorf("pattern \"a\" offsets = %v, want %v", gotA, wantA)
+	}
+}
+
+func TestMultiSearchEmptyHaystack(t *testing.T) {
+	matches := MultiSearch("", []string{""})
+	want := []Match{{0, 0}}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("MultiSearch(\"\", [\"\"]) = %v, want %v", matches, want)
+	}
+}
+
+func TestSearchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, haystack string
+		want              bool
+	}{
+		{"foo*bar", "foobar", true},
+		{"foo*bar", "foo---bar", true},
+		{"foo*bar", "foobarx", false},
+		{"foo*bar", "xfoobar", false},
+		{"**/x", "a/b/x", true},
+		{"**/x", "dirx", false},
+		{"**/x", "a/b/y", false},
+		{"?oo", "foo", true},
+		{"?oo", "zoo", true},
+		{"?oo", "too long", false},
+		{"?oo", "fo", false},
+	}
+
+	for _, c := range cases {
+		got := SearchGlob(c.haystack, c.pattern, 2)
+		gotMatch := len(got) > 0
+		if gotMatch != c.want {
+			t.Errorf(
+				"SearchGlob(%q, %q, 2) matched = %v, want %v",
+				c.haystack, c.pattern, gotMatch, c.want,
+			)
+		}
+	}
+}
+
+func TestSearchGlobNoPanicOnShortRemainder(t *testing.T) {
+	// Regression test: a non-edge literal fragment with nothing left to
+	// search used to divide by zero inside splitKmpWork.
+	if got := SearchGlob("xa", "*a*b*", 2); got != nil {
+		t.Errorf("SearchGlob(\"xa\", \"*a*b*\", 2) = %v, want nil", got)
+	}
+	if got := SearchGlob("", "*a*", 2); got != nil {
+		t.Errorf("SearchGlob(\"\", \"*a*\", 2) = %v, want nil", got)
+	}
+}
+
+func TestParallelFuzzyFindAgreesWithFuzzyFindOnTies(t *testing.T) {
+	// Regression test: this haystack has many lines that tie on score,
+	// matched length, and line length against needle " ", so the top-K
+	// set used to depend on n_ranges instead of just haystack/needle/k.
+	haystack := "/C\nCCaCa\n-/b\na/_b \n _baba_\nb-  _C/\n/  b/b_\n CbbaC_\n_ba-"
+	want := FuzzyFind(haystack, " ", 3)
+	for n := 1; n <= 5; n++ {
+		got := ParallelFuzzyFind(haystack, " ", 3, n)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf(
+				"ParallelFuzzyFind(haystack, \" \", 3, %d) = %v, want %v (FuzzyFind result)",
+				n, got, want,
+			)
+		}
+	}
+}